@@ -87,6 +87,9 @@ func main() {
 	tioctr := controllers.NewImageIO(tiosvc, usrsvc)
 	moctrl := controllers.NewMetric()
 
+	// controllers.NewImagePrune isn't started here yet: it needs a
+	// PruneJob informer this tree doesn't carry. See its doc comment.
+
 	// starts up all informers and waits for their cache to sync up,
 	// only then we start the controllers i.e. start to process events
 	// from the queue.
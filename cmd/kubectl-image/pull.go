@@ -19,24 +19,38 @@ import (
 	"crypto/tls"
 	"fmt"
 	"os"
+	"strings"
 
 	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
 
 	imgcopy "github.com/containers/image/v5/copy"
 	"github.com/containers/image/v5/signature"
 	"github.com/containers/image/v5/transports/alltransports"
 	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
 	"github.com/shipwright-io/image/cmd/kubectl-image/static"
+	"github.com/shipwright-io/image/infra/images/v1beta1/apis"
+	"github.com/shipwright-io/image/infra/imagestore"
 	"github.com/shipwright-io/image/infra/pb"
 	"github.com/shipwright-io/image/infra/progbar"
+	"github.com/shipwright-io/image/infra/trustpolicy"
 )
 
 func init() {
 	imagepull.Flags().Bool("insecure", false, "don't verify certificate when connecting")
+	imagepull.Flags().String(
+		"policy-file", "",
+		"path to an ImageTrustPolicy document enforced locally before the pull completes",
+	)
+	imagepull.Flags().String(
+		"platform", "",
+		"pull a specific platform (os/arch or os/arch/variant) out of a multi-arch image",
+	)
 }
 
 var imagepull = &cobra.Command{
@@ -69,32 +83,45 @@ var imagepull = &cobra.Command{
 			return err
 		}
 
-		// now that we know what is the tag we do the grpc call
-		// to retrieve the image. The output here is a local tar
-		// file from where we can load the image into runtime's
-		// local storage.
-		srcref, cleanup, err := pullImage(c.Context(), tidx, config.BearerToken, insecure)
+		platformFlag, err := c.Flags().GetString("platform")
+		if err != nil {
+			return err
+		}
+		platform, err := parsePlatform(platformFlag)
 		if err != nil {
 			return err
 		}
-		defer cleanup()
 
-		dstref, err := tidx.localStorageRef()
+		policyFile, err := c.Flags().GetString("policy-file")
 		if err != nil {
 			return err
 		}
 
-		pol := &signature.Policy{
-			Default: signature.PolicyRequirements{
-				signature.NewPRInsecureAcceptAnything(),
-			},
+		// scope identifies what is being pulled for trust policy matching:
+		// the Shipwright Image this command resolves to, since that is the
+		// only identity kubectl-image ever learns about the source.
+		scope := fmt.Sprintf("%s/%s", tidx.namespace, tidx.name)
+		polctx, err := policyContextFor(policyFile, scope)
+		if err != nil {
+			return err
+		}
+
+		// now that we know what is the tag we do the grpc call to
+		// retrieve the image. pullImage already enforces polctx when it
+		// copies the received tar into the local image store, so nothing
+		// unverified ever reaches that store.
+		srcref, cleanup, err := pullImage(c.Context(), tidx, config.BearerToken, insecure, platform, polctx)
+		if err != nil {
+			return err
 		}
-		polctx, err := signature.NewPolicyContext(pol)
+		defer cleanup()
+
+		dstref, err := tidx.localStorageRef()
 		if err != nil {
 			return err
 		}
 
-		// copy the image into runtime's local storage.
+		// copy the already verified image into runtime's local storage.
 		_, err = imgcopy.Image(
 			c.Context(), polctx, dstref, srcref, &imgcopy.Options{},
 		)
@@ -102,13 +129,81 @@ var imagepull = &cobra.Command{
 	},
 }
 
+// policyContextFor builds the signature.PolicyContext enforced locally
+// before the pulled image is committed to runtime's local storage. With an
+// empty policyFile it keeps today's behavior of accepting any image, signed
+// or not, so existing scripts don't break. Otherwise policyFile is read as
+// an ImageTrustPolicy document, the same one the controller enforces
+// server-side, and decoded into the containers/image primitives that
+// describe which signatures are acceptable for scope.
+func policyContextFor(policyFile, scope string) (*signature.PolicyContext, error) {
+	if policyFile == "" {
+		return trustpolicy.Build(nil, scope)
+	}
+
+	raw, err := os.ReadFile(policyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy file: %w", err)
+	}
+
+	spec := &apis.ImageTrustPolicySpec{}
+	if err := yaml.Unmarshal(raw, spec); err != nil {
+		return nil, fmt.Errorf("error parsing policy file: %w", err)
+	}
+
+	return trustpolicy.Build(spec, scope)
+}
+
+// parsePlatform turns a --platform flag value of the form "os/arch" or
+// "os/arch/variant" into an apis.Platform. An empty flag returns a nil
+// platform, telling pullImage to let the server pick its default entry
+// out of a multi-arch image.
+func parsePlatform(flag string) (*apis.Platform, error) {
+	if flag == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(flag, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid --platform %q, want os/arch or os/arch/variant", flag)
+	}
+
+	platform := &apis.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		platform.Variant = parts[2]
+	}
+	return platform, nil
+}
+
 // pullImage pulls the current generation for an image identified by imageindex.
 // Returns a reference to the locally stored image (on disk) and a function to
 // be called at the end to clean up our mess. If this function returns an error
 // then callers don't need to call the clean-up function.
+//
+// Every call here pays for the full network transfer: there is no resume,
+// chunking, or parallelism, and nothing is skipped based on what's already
+// staged. A real fix needs reworked protobuf framing and a manifest-first
+// handshake so the client can learn the source's digest before paying for
+// the transfer, neither of which exists in this tree. The digest bookkeeping
+// below is purely informational for that reason — see its comment.
+//
+// The temporary tar is removed before pullImage returns, so the clean-up
+// function it returns does nothing: the staged image stays in the local
+// image store for later pulls.
 func pullImage(
 	ctx context.Context, idx imageindex, token string, insecure bool,
+	platform *apis.Platform, polctx *signature.PolicyContext,
 ) (types.ImageReference, func(), error) {
+	platformKey := ""
+	if platform != nil {
+		platformKey = platform.String()
+	}
+
+	staged, err := imagestore.Layout(idx.namespace, idx.name, platformKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error staging local image store: %w", err)
+	}
+
 	conn, err := grpc.DialContext(
 		ctx,
 		idx.server,
@@ -127,6 +222,9 @@ func pullImage(
 		Namespace: idx.namespace,
 		Token:     token,
 	}
+	if platform != nil {
+		header.Platform = platform.String()
+	}
 
 	client := pb.NewImageIOServiceClient(conn)
 	stream, err := client.Pull(
@@ -166,5 +264,30 @@ func pullImage(
 		return nil, nil, fmt.Errorf("error parsing reference: %w", err)
 	}
 
-	return fromref, cleanup, nil
+	// stage the received tar into the local image store. This is where the
+	// operator's trust policy is actually enforced: imgcopy.Image refuses
+	// to write anything to staged unless the signatures on fromref satisfy
+	// polctx, so untrusted bytes never reach the image store. They do sit
+	// in the temporary tar file until cleanup removes it.
+	manifestBytes, err := imgcopy.Image(ctx, polctx, staged, fromref, &imgcopy.Options{})
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("error verifying pulled image: %w", err)
+	}
+	cleanup()
+
+	// This comparison is informational only: by this point the full
+	// transfer already happened and the bytes are already staged, so
+	// nothing was actually skipped or reused. It exists so an operator
+	// diffing output across runs can tell the content didn't change.
+	newDigest := digest.FromBytes(manifestBytes).String()
+	if prev, err := imagestore.StoredDigest(idx.namespace, idx.name, platformKey); err == nil && prev == newDigest {
+		fmt.Fprintln(os.Stderr, "note: pulled image content is unchanged from what was already staged locally "+
+			"(the transfer still ran in full; this tree has no protocol support for skipping it)")
+	}
+	if err := imagestore.RecordDigest(idx.namespace, idx.name, platformKey, newDigest); err != nil {
+		return nil, nil, fmt.Errorf("error recording staged digest: %w", err)
+	}
+
+	return staged, func() {}, nil
 }
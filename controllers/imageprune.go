@@ -0,0 +1,64 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shipwright-io/image/services"
+)
+
+// defaultPruneInterval is the interval NewImagePrune stores on the
+// controller. Nothing reads it or a PruneJob's IntervalSeconds while Start
+// does not reconcile.
+const defaultPruneInterval = 10 * time.Minute
+
+// ImagePrune is meant to periodically ask services.ImagePrune to
+// reconcile every PruneJob, relying on starter.Start's leader election so
+// only one imgctrl replica ever runs it.
+//
+// Status: partially done. The backlog request asked for this to be wired
+// into cmd/imgctrl/main.go as a leader-elected periodic reconciler; that
+// part isn't delivered. Listing PruneJob objects across namespaces needs
+// a generated informer for that CRD, which doesn't exist in this tree.
+// Rather than ship a reconcile loop with no lister behind it, Start
+// returns an error instead of looping, so an accidental wire-up fails
+// loudly rather than silently running an inert ticker that never prunes
+// anything. cmd/imgctrl/main.go correspondingly never constructs or
+// starts this controller.
+type ImagePrune struct {
+	svc      *services.ImagePrune
+	interval time.Duration
+}
+
+// NewImagePrune returns a controller ready to be handed to starter.New
+// once reconcile is implemented, following the same svc-wrapping shape
+// as the other controllers.
+func NewImagePrune(svc *services.ImagePrune) *ImagePrune {
+	return &ImagePrune{svc: svc, interval: defaultPruneInterval}
+}
+
+// Name identifies this controller in logs.
+func (c *ImagePrune) Name() string {
+	return "image-prune"
+}
+
+// Start returns an error: see the ImagePrune doc comment for why this
+// isn't wired into starter.New yet.
+func (c *ImagePrune) Start(ctx context.Context) error {
+	return fmt.Errorf("image-prune controller not wired: PruneJob informer is not available in this tree yet")
+}
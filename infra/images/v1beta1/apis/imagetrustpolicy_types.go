@@ -0,0 +1,139 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TrustRequirementType enumerates the ways a scope can require images to be
+// signed before ImageImport is allowed to pull them.
+type TrustRequirementType string
+
+const (
+	// TrustRequirementInsecureAcceptAnything accepts images regardless of
+	// signatures. This is the historical, implicit, behavior.
+	TrustRequirementInsecureAcceptAnything TrustRequirementType = "InsecureAcceptAnything"
+	// TrustRequirementReject never accepts images for the matching scope.
+	TrustRequirementReject TrustRequirementType = "Reject"
+	// TrustRequirementSigstore requires a valid sigstore/cosign signature,
+	// either keyed or keyless (Fulcio/Rekor identity based).
+	TrustRequirementSigstore TrustRequirementType = "Sigstore"
+	// TrustRequirementGPG requires a valid GPG signature from one of the
+	// provided keys.
+	TrustRequirementGPG TrustRequirementType = "GPG"
+)
+
+// FulcioIdentity pins the OIDC issuer and subject expected on the Fulcio
+// certificate used for a keyless sigstore signature.
+type FulcioIdentity struct {
+	// Issuer is the OIDC issuer that must have minted the signing identity.
+	Issuer string `json:"issuer"`
+	// Subject is the expected identity, matched against the certificate's
+	// subject email address.
+	Subject string `json:"subject"`
+}
+
+// SigstoreRequirement describes the keys or identities accepted for a
+// sigstore/cosign signature requirement.
+type SigstoreRequirement struct {
+	// PublicKeys holds PEM encoded public keys, any one of which is enough
+	// to satisfy the requirement.
+	// +optional
+	PublicKeys []string `json:"publicKeys,omitempty"`
+	// FulcioIdentities holds the keyless identities accepted through
+	// Fulcio certificates, verified against the configured Rekor log.
+	// Exactly one entry is supported today; a second is rejected rather
+	// than silently requiring both.
+	// +optional
+	FulcioIdentities []FulcioIdentity `json:"fulcioIdentities,omitempty"`
+	// RekorURL overrides the default Rekor transparency log used to
+	// verify keyless signatures.
+	// +optional
+	RekorURL string `json:"rekorURL,omitempty"`
+}
+
+// TrustRequirement binds a requirement type to the key material or
+// identities needed to satisfy it.
+type TrustRequirement struct {
+	// Type selects how images matching the scope must be validated.
+	Type TrustRequirementType `json:"type"`
+	// GPGPublicKeys holds armored GPG public keys, used when Type is GPG.
+	// +optional
+	GPGPublicKeys []string `json:"gpgPublicKeys,omitempty"`
+	// Sigstore holds the sigstore/cosign requirement, used when Type is
+	// Sigstore.
+	// +optional
+	Sigstore *SigstoreRequirement `json:"sigstore,omitempty"`
+}
+
+// TrustScope maps a registry/repository prefix to the requirement that
+// applies to images pulled from it.
+type TrustScope struct {
+	// Scope is a registry host, optionally followed by a repository path
+	// prefix, e.g. "registry.access.redhat.com" or
+	// "quay.io/shipwright-io/".
+	Scope string `json:"scope"`
+	// Requirement is the trust requirement enforced for this scope.
+	Requirement TrustRequirement `json:"requirement"`
+}
+
+// ImageTrustPolicySpec declares the signature requirements enforced on
+// ImageImport when pulling from the scopes it lists.
+type ImageTrustPolicySpec struct {
+	// Default is the requirement applied to scopes not otherwise listed.
+	// When empty, it defaults to TrustRequirementInsecureAcceptAnything so
+	// existing ImageImport objects keep working unmodified.
+	// +optional
+	Default *TrustRequirement `json:"default,omitempty"`
+	// Scopes lists the per registry/repository requirements, evaluated in
+	// order, the most specific scope prefix winning.
+	// +optional
+	Scopes []TrustScope `json:"scopes,omitempty"`
+}
+
+// ImageTrustPolicyStatus surfaces the last time the policy was validated
+// and any scopes that failed to parse into a containers/image requirement.
+type ImageTrustPolicyStatus struct {
+	// ObservedGeneration is the most recent generation reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions reports the validity of the policy document.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ImageTrustPolicy is a cluster scoped resource describing which
+// signatures ImageImport must require before pulling from a given
+// registry or repository.
+type ImageTrustPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ImageTrustPolicySpec   `json:"spec,omitempty"`
+	Status ImageTrustPolicyStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ImageTrustPolicyList is a list of ImageTrustPolicy.
+type ImageTrustPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ImageTrustPolicy `json:"items"`
+}
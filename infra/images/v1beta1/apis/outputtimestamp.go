@@ -0,0 +1,35 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+// Well-known OutputTimestamp values. Any other value is parsed as an
+// RFC3339 timestamp by infra/reproducible.ParseOutputTimestamp.
+const (
+	// OutputTimestampZero rewrites every mtime and the config's created
+	// field to the Unix epoch, matching what most reproducible build
+	// tooling defaults to.
+	OutputTimestampZero = "Zero"
+	// OutputTimestampSourceTimestamp reuses the source image's config
+	// created field for every layer and the rewritten config.
+	OutputTimestampSourceTimestamp = "SourceTimestamp"
+	// OutputTimestampBuildTimestamp stamps the time the ImageImport
+	// actually ran, i.e. today's implicit behavior made explicit.
+	OutputTimestampBuildTimestamp = "BuildTimestamp"
+)
+
+// ConditionOutputTimestampValueNotSupported is set on an ImageImport
+// whose Spec.OutputTimestamp is neither a well-known value nor a valid
+// RFC3339 timestamp.
+const ConditionOutputTimestampValueNotSupported = "OutputTimestampValueNotSupported"
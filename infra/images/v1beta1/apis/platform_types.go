@@ -0,0 +1,62 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+// Platform identifies one entry of a manifest list / OCI image index.
+type Platform struct {
+	// OS is the operating system, e.g. "linux".
+	OS string `json:"os"`
+	// Architecture is the CPU architecture, e.g. "arm64".
+	Architecture string `json:"architecture"`
+	// Variant refines Architecture, e.g. "v8" for arm64 variants.
+	// +optional
+	Variant string `json:"variant,omitempty"`
+}
+
+// String renders p as the "os/arch" or "os/arch/variant" form used by
+// --platform flags and error messages.
+func (p Platform) String() string {
+	if p.Variant == "" {
+		return p.OS + "/" + p.Architecture
+	}
+	return p.OS + "/" + p.Architecture + "/" + p.Variant
+}
+
+// Matches reports whether p and other refer to the same platform. An
+// empty Variant on either side is treated as a wildcard, since most
+// source manifests don't set one.
+func (p Platform) Matches(other Platform) bool {
+	if p.OS != other.OS || p.Architecture != other.Architecture {
+		return false
+	}
+	return p.Variant == "" || other.Variant == "" || p.Variant == other.Variant
+}
+
+// ImportMode controls how ImageImport handles a source that resolves to
+// a manifest list / OCI image index instead of a single manifest.
+type ImportMode string
+
+const (
+	// ImportModeAll imports every platform entry of the source manifest
+	// list, pushing the same manifest list to the mirror registry.
+	ImportModeAll ImportMode = "All"
+	// ImportModeSelected imports only the platforms listed in
+	// Spec.Platforms, failing if none of the source's entries match.
+	ImportModeSelected ImportMode = "Selected"
+	// ImportModePreferred imports the entry matching the node's
+	// platform, falling back to the source's first entry when no
+	// entry matches.
+	ImportModePreferred ImportMode = "Preferred"
+)
@@ -0,0 +1,44 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import "testing"
+
+func TestPlatformString(t *testing.T) {
+	if got := (Platform{OS: "linux", Architecture: "arm64"}).String(); got != "linux/arm64" {
+		t.Fatalf("expected linux/arm64, got %q", got)
+	}
+	if got := (Platform{OS: "linux", Architecture: "arm", Variant: "v7"}).String(); got != "linux/arm/v7" {
+		t.Fatalf("expected linux/arm/v7, got %q", got)
+	}
+}
+
+func TestPlatformMatches(t *testing.T) {
+	a := Platform{OS: "linux", Architecture: "arm", Variant: "v7"}
+	b := Platform{OS: "linux", Architecture: "arm", Variant: "v8"}
+	if a.Matches(b) {
+		t.Fatal("expected differing variants to not match")
+	}
+
+	noVariant := Platform{OS: "linux", Architecture: "arm"}
+	if !a.Matches(noVariant) {
+		t.Fatal("expected an empty variant to act as a wildcard")
+	}
+
+	different := Platform{OS: "windows", Architecture: "amd64"}
+	if a.Matches(different) {
+		t.Fatal("expected differing OS/architecture to not match")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PruneJobSpec describes which Image generations are eligible for
+// reclaiming and whether the job is allowed to actually delete them.
+type PruneJobSpec struct {
+	// Filters selects the generations considered for pruning, using the
+	// same filter language container image tooling already exposes, e.g.
+	// "until=168h", "label=stage=ci", "dangling=true", "generations>5".
+	// Setting All is the only way to prune everything; an empty list
+	// without All matches nothing.
+	// +optional
+	Filters []string `json:"filters,omitempty"`
+	// All, when true, ignores Filters and considers every generation
+	// eligible, the current one included.
+	// +optional
+	All bool `json:"all,omitempty"`
+	// DryRun computes and reports the reclaimable set without deleting
+	// anything. Defaults to true so a freshly created PruneJob is safe.
+	// +optional
+	DryRun *bool `json:"dryRun,omitempty"`
+	// IntervalSeconds is how often the reconciler re-evaluates this job.
+	// +optional
+	IntervalSeconds int64 `json:"intervalSeconds,omitempty"`
+}
+
+// PruneJobStatus reports what the last reconciliation found, without
+// implying anything was deleted unless DryRun was false.
+type PruneJobStatus struct {
+	// ObservedGeneration is the most recent spec generation reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastRunTime is when the filters were last evaluated.
+	// +optional
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+	// ReclaimableBytes is the size of the generations matching Filters as
+	// of LastRunTime, whether or not they were actually deleted.
+	// +optional
+	ReclaimableBytes int64 `json:"reclaimableBytes,omitempty"`
+	// ReclaimedBytes is the size actually deleted on the last run where
+	// DryRun was false.
+	// +optional
+	ReclaimedBytes int64 `json:"reclaimedBytes,omitempty"`
+	// Conditions reports the health of the job itself, e.g. a malformed
+	// filter.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PruneJob periodically reclaims old Image generations from the backing
+// storage, matched through Spec.Filters.
+type PruneJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PruneJobSpec   `json:"spec,omitempty"`
+	Status PruneJobStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PruneJobList is a list of PruneJob.
+type PruneJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PruneJob `json:"items"`
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ShortNameMode controls what happens when an ImageImport's From doesn't
+// carry a registry host.
+type ShortNameMode string
+
+const (
+	// ShortNameModePermissive falls back to DefaultRegistry when a short
+	// name doesn't match any alias. This is the zero-value behavior.
+	ShortNameModePermissive ShortNameMode = "Permissive"
+	// ShortNameModeEnforcing rejects any short name that doesn't match an
+	// alias, instead of guessing a registry for it.
+	ShortNameModeEnforcing ShortNameMode = "Enforcing"
+)
+
+// ShortNameAlias maps a bare image name to the fully qualified repository
+// it should resolve to, e.g. "ubi8" to
+// "registry.access.redhat.com/ubi8/ubi".
+type ShortNameAlias struct {
+	// Name is the short name as it appears in an ImageImport's From.
+	Name string `json:"name"`
+	// Repository is the fully qualified repository Name resolves to.
+	Repository string `json:"repository"`
+}
+
+// RegistryMirror lists the pull-through fallbacks tried, in order, before
+// falling back to Source itself.
+type RegistryMirror struct {
+	// Source is the registry host mirrors apply to.
+	Source string `json:"source"`
+	// Mirrors is the ordered list of registry hosts tried before Source.
+	Mirrors []string `json:"mirrors"`
+}
+
+// RegistryOverride carries the per-host settings ImageImport needs when
+// talking to a registry outside the defaults containers/image ships with.
+type RegistryOverride struct {
+	// Host is the registry host these overrides apply to.
+	Host string `json:"host"`
+	// Insecure allows plain HTTP or a TLS connection without certificate
+	// verification against Host.
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+	// CABundle is a PEM encoded certificate bundle trusted for Host, in
+	// addition to the system trust store.
+	// +optional
+	CABundle string `json:"caBundle,omitempty"`
+}
+
+// RegistryConfigSpec is the cluster-scoped equivalent of registries.conf,
+// applied by services.NewImageImport when resolving an ImageImport's
+// From and building the types.SystemContext used to reach it.
+type RegistryConfigSpec struct {
+	// ShortNameMode controls how a From without a registry host behaves.
+	// +optional
+	ShortNameMode ShortNameMode `json:"shortNameMode,omitempty"`
+	// DefaultRegistry is used to qualify short names in Permissive mode.
+	// +optional
+	DefaultRegistry string `json:"defaultRegistry,omitempty"`
+	// ShortNameAliases lists the known short name to repository mappings.
+	// +optional
+	ShortNameAliases []ShortNameAlias `json:"shortNameAliases,omitempty"`
+	// Mirrors lists the pull-through fallback chains per registry.
+	// +optional
+	Mirrors []RegistryMirror `json:"mirrors,omitempty"`
+	// Overrides lists the per-host insecure/CA settings.
+	// +optional
+	Overrides []RegistryOverride `json:"overrides,omitempty"`
+}
+
+// RegistryConfigStatus reports issues found while applying the config,
+// e.g. an ambiguous alias.
+type RegistryConfigStatus struct {
+	// ObservedGeneration is the most recent generation reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions reports the validity of the configuration.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RegistryConfig is a cluster-scoped resource describing short-name
+// aliases, mirrors and per-host overrides applied to every ImageImport.
+type RegistryConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RegistryConfigSpec   `json:"spec,omitempty"`
+	Status RegistryConfigStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RegistryConfigList is a list of RegistryConfig.
+type RegistryConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []RegistryConfig `json:"items"`
+}
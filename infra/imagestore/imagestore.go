@@ -0,0 +1,123 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package imagestore gives kubectl-image a persistent, per platform
+// directory to land a verified pull in, instead of a throwaway temp file,
+// so repeated local copies (e.g. loading the same pull into more than one
+// container runtime) don't need a fresh network transfer.
+//
+// This intentionally does not skip the ImageIOServiceClient.Pull call on
+// its own: without the per-blob framing and manifest-first handshake a
+// real resumable transfer needs, there is no cheap way to learn the
+// source's current digest before paying for the full transfer, so
+// deciding "this is already up to date" ahead of time can't be done
+// safely here. Callers must always pull, then compare the result's
+// digest against StoredDigest before trusting a previous copy.
+package imagestore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/types"
+)
+
+// digestFile is the sidecar recording the digest last verified and
+// staged into a given namespace/name/platform directory.
+const digestFile = "shipwright.digest"
+
+// Layout returns the local OCI layout reference used to stage the pull
+// for namespace/name/platform, creating the backing directory if needed.
+// platform should be the same value passed to --platform (empty for "no
+// platform requested"); mixing platforms under the same path would let a
+// pull of one platform silently overwrite another.
+func Layout(namespace, name, platform string) (types.ImageReference, error) {
+	dir, err := dirFor(namespace, name, platform)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving image store dir: %w", err)
+	}
+
+	ref, err := layout.ParseReference(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error referencing image store dir: %w", err)
+	}
+	return ref, nil
+}
+
+// StoredDigest returns the digest recorded for namespace/name/platform by
+// the last successful RecordDigest call, or "" when nothing is staged
+// yet.
+func StoredDigest(namespace, name, platform string) (string, error) {
+	dir, err := dirFor(namespace, name, platform)
+	if err != nil {
+		return "", fmt.Errorf("error resolving image store dir: %w", err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, digestFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading stored digest: %w", err)
+	}
+	return string(raw), nil
+}
+
+// RecordDigest persists digest as the last verified content staged into
+// namespace/name/platform. Call it only after the image at that location
+// has been copied through the operator's trust policy.
+func RecordDigest(namespace, name, platform, digest string) error {
+	dir, err := dirFor(namespace, name, platform)
+	if err != nil {
+		return fmt.Errorf("error resolving image store dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, digestFile), []byte(digest), 0o640); err != nil {
+		return fmt.Errorf("error recording staged digest: %w", err)
+	}
+	return nil
+}
+
+// dirFor returns the on-disk directory used to stage namespace/name/platform,
+// creating it when it doesn't exist yet.
+func dirFor(namespace, name, platform string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return mkStorePath(home, namespace, name, platform)
+}
+
+// mkStorePath joins baseDir with the namespace/name/platform segments and
+// ensures the resulting directory exists. Split out from dirFor so the
+// path construction can be unit tested without touching $HOME.
+func mkStorePath(baseDir, namespace, name, platform string) (string, error) {
+	dir := storePath(baseDir, namespace, name, platform)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// storePath is the pure path-construction half of mkStorePath. An empty
+// platform stages under "_" rather than being dropped, so the "no platform
+// requested" directory is never a parent of a platform's directory. A
+// literal platform "_" would share that directory.
+func storePath(baseDir, namespace, name, platform string) string {
+	if platform == "" {
+		platform = "_"
+	}
+	return filepath.Join(baseDir, ".local", "share", "kubectl-image", "store", namespace, name, platform)
+}
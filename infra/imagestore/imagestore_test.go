@@ -0,0 +1,38 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagestore
+
+import "testing"
+
+func TestStorePathKeyedByPlatform(t *testing.T) {
+	linux := storePath("/home/u", "ns", "img", "linux/amd64")
+	arm := storePath("/home/u", "ns", "img", "linux/arm64")
+	none := storePath("/home/u", "ns", "img", "")
+
+	if linux == arm {
+		t.Fatalf("different platforms must not share a store path: %q", linux)
+	}
+	if linux == none || arm == none {
+		t.Fatalf("a specific platform must not collide with the no-platform path")
+	}
+}
+
+func TestStorePathStableForSameInputs(t *testing.T) {
+	a := storePath("/home/u", "ns", "img", "linux/amd64")
+	b := storePath("/home/u", "ns", "img", "linux/amd64")
+	if a != b {
+		t.Fatalf("storePath must be deterministic, got %q and %q", a, b)
+	}
+}
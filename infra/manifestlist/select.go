@@ -0,0 +1,89 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package manifestlist picks which entries of a manifest list / OCI
+// image index to keep, given an ImageImport's Spec.ImportMode and
+// Spec.Platforms. It has no callers yet: wiring Select into
+// services.NewImageImport, so it actually drives what gets pushed to
+// the mirror registry, is pending that service landing in this tree.
+package manifestlist
+
+import (
+	"fmt"
+
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/shipwright-io/image/infra/images/v1beta1/apis"
+)
+
+// Select returns the descriptors of idx that ImportMode/platforms keep,
+// in source order. nodePlatform is only consulted for
+// apis.ImportModePreferred.
+func Select(
+	idx specs.Index, mode apis.ImportMode, platforms []apis.Platform, nodePlatform apis.Platform,
+) ([]specs.Descriptor, error) {
+	switch mode {
+	case "", apis.ImportModeAll:
+		return idx.Manifests, nil
+
+	case apis.ImportModeSelected:
+		if len(platforms) == 0 {
+			return nil, fmt.Errorf("importMode Selected requires at least one entry in platforms")
+		}
+		selected := filter(idx, func(p apis.Platform) bool {
+			for _, want := range platforms {
+				if want.Matches(p) {
+					return true
+				}
+			}
+			return false
+		})
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("none of the requested platforms matched the source manifest list")
+		}
+		return selected, nil
+
+	case apis.ImportModePreferred:
+		preferred := filter(idx, nodePlatform.Matches)
+		if len(preferred) > 0 {
+			return preferred[:1], nil
+		}
+		if len(idx.Manifests) == 0 {
+			return nil, fmt.Errorf("source manifest list has no entries")
+		}
+		return idx.Manifests[:1], nil
+
+	default:
+		return nil, fmt.Errorf("unknown importMode %q", mode)
+	}
+}
+
+// filter returns the descriptors of idx whose platform satisfies keep.
+func filter(idx specs.Index, keep func(apis.Platform) bool) []specs.Descriptor {
+	var out []specs.Descriptor
+	for _, d := range idx.Manifests {
+		if d.Platform == nil {
+			continue
+		}
+		p := apis.Platform{
+			OS:           d.Platform.OS,
+			Architecture: d.Platform.Architecture,
+			Variant:      d.Platform.Variant,
+		}
+		if keep(p) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifestlist
+
+import (
+	"testing"
+
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/shipwright-io/image/infra/images/v1beta1/apis"
+)
+
+func testIndex() specs.Index {
+	return specs.Index{
+		Manifests: []specs.Descriptor{
+			{Digest: "sha256:amd64", Platform: &specs.Platform{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:arm64", Platform: &specs.Platform{OS: "linux", Architecture: "arm64"}},
+		},
+	}
+}
+
+func TestSelectAllReturnsEverything(t *testing.T) {
+	got, err := Select(testIndex(), apis.ImportModeAll, nil, apis.Platform{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected both entries, got %d", len(got))
+	}
+}
+
+func TestSelectSelectedRequiresPlatforms(t *testing.T) {
+	if _, err := Select(testIndex(), apis.ImportModeSelected, nil, apis.Platform{}); err == nil {
+		t.Fatal("expected an error when no platforms are given")
+	}
+}
+
+func TestSelectSelectedMatches(t *testing.T) {
+	got, err := Select(testIndex(), apis.ImportModeSelected, []apis.Platform{{OS: "linux", Architecture: "arm64"}}, apis.Platform{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Digest != "sha256:arm64" {
+		t.Fatalf("expected only the arm64 entry, got %+v", got)
+	}
+}
+
+func TestSelectSelectedNoMatchIsError(t *testing.T) {
+	platforms := []apis.Platform{{OS: "windows", Architecture: "amd64"}}
+	if _, err := Select(testIndex(), apis.ImportModeSelected, platforms, apis.Platform{}); err == nil {
+		t.Fatal("expected an error when no requested platform matches")
+	}
+}
+
+func TestSelectPreferredMatchesNodePlatform(t *testing.T) {
+	got, err := Select(testIndex(), apis.ImportModePreferred, nil, apis.Platform{OS: "linux", Architecture: "arm64"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Digest != "sha256:arm64" {
+		t.Fatalf("expected the arm64 entry, got %+v", got)
+	}
+}
+
+func TestSelectPreferredFallsBackToFirstEntry(t *testing.T) {
+	got, err := Select(testIndex(), apis.ImportModePreferred, nil, apis.Platform{OS: "windows", Architecture: "amd64"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Digest != "sha256:amd64" {
+		t.Fatalf("expected the first entry as fallback, got %+v", got)
+	}
+}
+
+func TestSelectPreferredEmptyIndexIsError(t *testing.T) {
+	if _, err := Select(specs.Index{}, apis.ImportModePreferred, nil, apis.Platform{}); err == nil {
+		t.Fatal("expected an error for an empty manifest list")
+	}
+}
+
+func TestSelectUnknownModeIsError(t *testing.T) {
+	if _, err := Select(testIndex(), apis.ImportMode("bogus"), nil, apis.Platform{}); err == nil {
+		t.Fatal("expected an error for an unknown import mode")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package registryconfig applies a RegistryConfig's short-name aliases,
+// mirrors and per-host overrides the same way services.NewImageImport
+// applies today's implicit containers/image defaults: once, while
+// building the types.SystemContext for a pull and while parsing the
+// ImageImport's From field.
+package registryconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/v5/types"
+
+	"github.com/shipwright-io/image/infra/images/v1beta1/apis"
+)
+
+// ErrAmbiguousShortName is returned by Resolve when From has no registry
+// host, doesn't match any alias, and cfg is in Enforcing mode.
+type ErrAmbiguousShortName struct {
+	From string
+}
+
+func (e *ErrAmbiguousShortName) Error() string {
+	return fmt.Sprintf("short name %q is ambiguous in enforcing mode: no alias matched", e.From)
+}
+
+// Resolve qualifies from against cfg's short-name aliases and default
+// registry, returning the fully qualified reference ImageImport should
+// use as its effective source. from is returned unchanged when it
+// already carries a registry host (i.e. contains a "/" and its leftmost
+// segment contains a "." or ":" or is "localhost").
+func Resolve(cfg *apis.RegistryConfigSpec, from string) (string, error) {
+	if cfg == nil || hasRegistryHost(from) {
+		return from, nil
+	}
+
+	for _, alias := range cfg.ShortNameAliases {
+		if alias.Name == from {
+			return alias.Repository, nil
+		}
+	}
+
+	if cfg.ShortNameMode == apis.ShortNameModeEnforcing {
+		return "", &ErrAmbiguousShortName{From: from}
+	}
+
+	if cfg.DefaultRegistry == "" {
+		return from, nil
+	}
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(cfg.DefaultRegistry, "/"), from), nil
+}
+
+// MirrorsFor returns the ordered pull-through fallback hosts configured
+// for host, not including host itself.
+func MirrorsFor(cfg *apis.RegistryConfigSpec, host string) []string {
+	if cfg == nil {
+		return nil
+	}
+	for _, m := range cfg.Mirrors {
+		if m.Source == host {
+			return m.Mirrors
+		}
+	}
+	return nil
+}
+
+// ApplyOverrides sets the per-host insecure/CA settings from cfg onto
+// sys, the types.SystemContext services.NewImageImport uses to reach the
+// source registry. When an override carries a CABundle, it is written
+// under host's subdirectory of certRootDir and sys.DockerPerHostCertDirPath
+// is pointed at the root, not the subdirectory: containers/image itself
+// joins DockerPerHostCertDirPath with host:port when looking up certs
+// (mirroring /etc/containers/certs.d/<host>/...), so pointing it directly
+// at the host directory would make containers/image look one level too
+// deep and never find the bundle.
+func ApplyOverrides(cfg *apis.RegistryConfigSpec, host string, sys *types.SystemContext) error {
+	if cfg == nil || sys == nil {
+		return nil
+	}
+	for _, o := range cfg.Overrides {
+		if o.Host != host {
+			continue
+		}
+		if o.Insecure {
+			sys.DockerInsecureSkipTLSVerify = types.OptionalBoolTrue
+		}
+		if o.CABundle != "" {
+			root, err := certRootDir()
+			if err != nil {
+				return fmt.Errorf("error resolving CA cert root dir: %w", err)
+			}
+			hostDir := filepath.Join(root, host)
+			if err := os.MkdirAll(hostDir, 0o750); err != nil {
+				return fmt.Errorf("error creating CA cert dir for %s: %w", host, err)
+			}
+			if err := os.WriteFile(filepath.Join(hostDir, "ca.crt"), []byte(o.CABundle), 0o640); err != nil {
+				return fmt.Errorf("error writing CA bundle for %s: %w", host, err)
+			}
+			sys.DockerPerHostCertDirPath = root
+		}
+		return nil
+	}
+	return nil
+}
+
+// certRootDir returns (creating if necessary) the directory
+// ApplyOverrides stages per-host CA bundles under, one subdirectory per
+// host, for containers/image's own host:port-keyed lookup to join onto.
+func certRootDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "shipwright-image", "registry-ca")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// hasRegistryHost reports whether ref already names an explicit registry
+// host, the same heuristic containers/image's short-name resolution
+// uses: a host needs either a port/"localhost" or a dot before the first
+// path separator.
+func hasRegistryHost(ref string) bool {
+	firstSegment := ref
+	if idx := strings.Index(ref, "/"); idx >= 0 {
+		firstSegment = ref[:idx]
+	} else {
+		return false
+	}
+	return strings.ContainsAny(firstSegment, ".:") || firstSegment == "localhost"
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registryconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/v5/types"
+
+	"github.com/shipwright-io/image/infra/images/v1beta1/apis"
+)
+
+func TestResolveNilConfigPassesThrough(t *testing.T) {
+	got, err := Resolve(nil, "alpine")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "alpine" {
+		t.Fatalf("expected alpine unchanged, got %q", got)
+	}
+}
+
+func TestResolveAlreadyQualifiedPassesThrough(t *testing.T) {
+	cfg := &apis.RegistryConfigSpec{DefaultRegistry: "registry.example.com"}
+	got, err := Resolve(cfg, "quay.io/foo/bar")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "quay.io/foo/bar" {
+		t.Fatalf("expected already-qualified ref unchanged, got %q", got)
+	}
+}
+
+func TestResolveShortNameAlias(t *testing.T) {
+	cfg := &apis.RegistryConfigSpec{
+		ShortNameAliases: []apis.ShortNameAlias{
+			{Name: "alpine", Repository: "quay.io/library/alpine"},
+		},
+	}
+	got, err := Resolve(cfg, "alpine")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "quay.io/library/alpine" {
+		t.Fatalf("expected alias match, got %q", got)
+	}
+}
+
+func TestResolveEnforcingRejectsUnmatchedShortName(t *testing.T) {
+	cfg := &apis.RegistryConfigSpec{ShortNameMode: apis.ShortNameModeEnforcing}
+	_, err := Resolve(cfg, "alpine")
+	if err == nil {
+		t.Fatal("expected an error for an unmatched short name in enforcing mode")
+	}
+	var ambiguous *ErrAmbiguousShortName
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected ErrAmbiguousShortName, got %T", err)
+	}
+}
+
+func TestResolvePermissiveFallsBackToDefaultRegistry(t *testing.T) {
+	cfg := &apis.RegistryConfigSpec{DefaultRegistry: "registry.example.com/"}
+	got, err := Resolve(cfg, "alpine")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "registry.example.com/alpine" {
+		t.Fatalf("expected default registry prefix, got %q", got)
+	}
+}
+
+func TestResolvePermissiveNoDefaultRegistryPassesThrough(t *testing.T) {
+	cfg := &apis.RegistryConfigSpec{}
+	got, err := Resolve(cfg, "alpine")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "alpine" {
+		t.Fatalf("expected alpine unchanged, got %q", got)
+	}
+}
+
+func TestMirrorsForNilConfig(t *testing.T) {
+	if got := MirrorsFor(nil, "docker.io"); got != nil {
+		t.Fatalf("expected nil mirrors for a nil config, got %v", got)
+	}
+}
+
+func TestMirrorsForMatch(t *testing.T) {
+	cfg := &apis.RegistryConfigSpec{
+		Mirrors: []apis.RegistryMirror{
+			{Source: "docker.io", Mirrors: []string{"mirror.example.com"}},
+		},
+	}
+	got := MirrorsFor(cfg, "docker.io")
+	if len(got) != 1 || got[0] != "mirror.example.com" {
+		t.Fatalf("expected one mirror, got %v", got)
+	}
+}
+
+func TestMirrorsForNoMatch(t *testing.T) {
+	cfg := &apis.RegistryConfigSpec{
+		Mirrors: []apis.RegistryMirror{
+			{Source: "docker.io", Mirrors: []string{"mirror.example.com"}},
+		},
+	}
+	if got := MirrorsFor(cfg, "quay.io"); got != nil {
+		t.Fatalf("expected no mirrors for an unconfigured host, got %v", got)
+	}
+}
+
+func TestApplyOverridesWritesCABundleUnderCertRoot(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	const host = "myregistry.example.com"
+	cfg := &apis.RegistryConfigSpec{
+		Overrides: []apis.RegistryOverride{
+			{Host: host, Insecure: true, CABundle: "-----BEGIN CERTIFICATE-----\nfake\n-----END CERTIFICATE-----\n"},
+		},
+	}
+
+	sys := &types.SystemContext{}
+	if err := ApplyOverrides(cfg, host, sys); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sys.DockerInsecureSkipTLSVerify != types.OptionalBoolTrue {
+		t.Fatalf("expected insecure override to be applied, got %v", sys.DockerInsecureSkipTLSVerify)
+	}
+
+	root, err := certRootDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sys.DockerPerHostCertDirPath != root {
+		t.Fatalf("expected DockerPerHostCertDirPath to be the cert root %q, got %q", root, sys.DockerPerHostCertDirPath)
+	}
+
+	// containers/image joins DockerPerHostCertDirPath with host:port
+	// itself, so the bundle must live one level below root, not at root.
+	raw, err := os.ReadFile(filepath.Join(root, host, "ca.crt"))
+	if err != nil {
+		t.Fatalf("expected a ca.crt under the host subdirectory of root: %v", err)
+	}
+	if string(raw) != cfg.Overrides[0].CABundle {
+		t.Fatalf("expected the written bundle to match the configured one, got %q", raw)
+	}
+}
+
+func TestApplyOverridesNoMatchingHostIsNoop(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	cfg := &apis.RegistryConfigSpec{
+		Overrides: []apis.RegistryOverride{
+			{Host: "other.example.com", Insecure: true, CABundle: "bundle"},
+		},
+	}
+	sys := &types.SystemContext{}
+	if err := ApplyOverrides(cfg, "myregistry.example.com", sys); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sys.DockerInsecureSkipTLSVerify == types.OptionalBoolTrue {
+		t.Fatal("expected no override to be applied for a non-matching host")
+	}
+	if sys.DockerPerHostCertDirPath != "" {
+		t.Fatalf("expected DockerPerHostCertDirPath to stay empty, got %q", sys.DockerPerHostCertDirPath)
+	}
+}
+
+func TestApplyOverridesNilConfigIsNoop(t *testing.T) {
+	sys := &types.SystemContext{}
+	if err := ApplyOverrides(nil, "myregistry.example.com", sys); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
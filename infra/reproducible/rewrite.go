@@ -0,0 +1,145 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reproducible normalizes the timestamps embedded in an image so
+// two runs of the same ImageImport produce byte-identical layers,
+// config, and manifest. The rewriting here works against plain tar
+// streams and decoded OCI structs; wiring it into a custom
+// types.ImageDestination, so services.NewImageImport can rewrite blobs
+// in place while copying with containers/image, is left for when that
+// service lands in this tree.
+package reproducible
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/shipwright-io/image/infra/images/v1beta1/apis"
+)
+
+// ParseOutputTimestamp turns an ImageImport's Spec.OutputTimestamp into
+// the concrete time every layer's mtimes and the rewritten config's
+// created field should carry. sourceCreated and buildTime back the
+// SourceTimestamp and BuildTimestamp well-known values respectively. An
+// unsupported value is reported through the returned error, which the
+// caller should surface as the OutputTimestampValueNotSupported
+// condition.
+func ParseOutputTimestamp(value string, sourceCreated, buildTime time.Time) (time.Time, error) {
+	switch value {
+	case "", apis.OutputTimestampBuildTimestamp:
+		return buildTime, nil
+	case apis.OutputTimestampZero:
+		return time.Unix(0, 0).UTC(), nil
+	case apis.OutputTimestampSourceTimestamp:
+		return sourceCreated, nil
+	default:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unsupported outputTimestamp %q: %w", value, err)
+		}
+		return t, nil
+	}
+}
+
+// RewriteTarMtimes copies src to dst, rewriting every tar entry's
+// modification, access and change times to stamp and writing every entry
+// in PAX format, while leaving everything else, including uid/gid/xattrs,
+// untouched. Normalizing those too is deliberately left out: most
+// reproducible-build consumers only need timestamps collapsed, and
+// touching ownership would silently break images that rely on it. The
+// returned digest is the canonical digest of dst's contents, ready to
+// replace the layer's entry in the manifest via RewriteManifestLayer.
+func RewriteTarMtimes(dst io.Writer, src io.Reader, stamp time.Time) (digest.Digest, error) {
+	digester := digest.Canonical.Digester()
+	tw := tar.NewWriter(io.MultiWriter(dst, digester.Hash()))
+	tr := tar.NewReader(src)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading tar entry: %w", err)
+		}
+
+		hdr.ModTime = stamp
+		hdr.AccessTime = stamp
+		hdr.ChangeTime = stamp
+		// USTAR cannot encode access and change times.
+		hdr.Format = tar.FormatPAX
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return "", fmt.Errorf("error writing tar header for %q: %w", hdr.Name, err)
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return "", fmt.Errorf("error copying tar entry %q: %w", hdr.Name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return "", fmt.Errorf("error closing rewritten tar: %w", err)
+	}
+	return digester.Digest(), nil
+}
+
+// RewriteConfigCreated sets config's Created field to stamp and returns
+// the re-marshaled config bytes together with their digest, ready to
+// replace the config blob and its entry in the manifest via
+// RewriteManifestConfig.
+func RewriteConfigCreated(config specs.Image, stamp time.Time) ([]byte, digest.Digest, error) {
+	config.Created = &stamp
+
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return nil, "", fmt.Errorf("error marshaling rewritten config: %w", err)
+	}
+	return raw, digest.FromBytes(raw), nil
+}
+
+// RewriteManifestConfig points manifest's Config descriptor at the
+// config blob identified by dgst/size, as produced by
+// RewriteConfigCreated.
+func RewriteManifestConfig(manifest specs.Manifest, dgst digest.Digest, size int64) specs.Manifest {
+	manifest.Config.Digest = dgst
+	manifest.Config.Size = size
+	return manifest
+}
+
+// RewriteManifestLayer points manifest's layer at index i at the blob
+// identified by dgst/size, as produced by RewriteTarMtimes. It returns
+// an error if i is out of range, since a caller passing a stale index
+// after the source manifest changed would otherwise silently rewrite
+// the wrong layer. manifest.Layers is copied before being mutated, so
+// the returned value never aliases the Layers backing array of the
+// manifest passed in — rewriting layer 0 from one call and layer 1 from
+// another, both derived from the same original manifest, won't stomp on
+// each other.
+func RewriteManifestLayer(manifest specs.Manifest, i int, dgst digest.Digest, size int64) (specs.Manifest, error) {
+	if i < 0 || i >= len(manifest.Layers) {
+		return manifest, fmt.Errorf("layer index %d out of range for a %d-layer manifest", i, len(manifest.Layers))
+	}
+	layers := make([]specs.Descriptor, len(manifest.Layers))
+	copy(layers, manifest.Layers)
+	layers[i].Digest = dgst
+	layers[i].Size = size
+	manifest.Layers = layers
+	return manifest, nil
+}
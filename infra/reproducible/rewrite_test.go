@@ -0,0 +1,203 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reproducible
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+	"time"
+
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestParseOutputTimestampWellKnownValues(t *testing.T) {
+	sourceCreated := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	buildTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	got, err := ParseOutputTimestamp("", sourceCreated, buildTime)
+	if err != nil || !got.Equal(buildTime) {
+		t.Fatalf("expected default to be buildTime, got %v, err %v", got, err)
+	}
+
+	got, err = ParseOutputTimestamp("SourceTimestamp", sourceCreated, buildTime)
+	if err != nil || !got.Equal(sourceCreated) {
+		t.Fatalf("expected SourceTimestamp to be sourceCreated, got %v, err %v", got, err)
+	}
+
+	got, err = ParseOutputTimestamp("Zero", sourceCreated, buildTime)
+	if err != nil || !got.Equal(time.Unix(0, 0).UTC()) {
+		t.Fatalf("expected Zero to be the Unix epoch, got %v, err %v", got, err)
+	}
+}
+
+func TestParseOutputTimestampRFC3339(t *testing.T) {
+	got, err := ParseOutputTimestamp("2021-06-01T00:00:00Z", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParseOutputTimestampRejectsGarbage(t *testing.T) {
+	if _, err := ParseOutputTimestamp("not-a-timestamp", time.Time{}, time.Time{}); err == nil {
+		t.Fatal("expected an error for an unsupported value")
+	}
+}
+
+func writeTar(t *testing.T, entries map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, body := range entries {
+		hdr := &tar.Header{Name: name, Size: int64(len(body)), Mode: 0o644}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("error writing header: %v", err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatalf("error writing body: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("error closing tar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRewriteTarMtimesIsDeterministic(t *testing.T) {
+	src := writeTar(t, map[string]string{"a": "hello", "b": "world"})
+	stamp := time.Unix(0, 0).UTC()
+
+	var dst1, dst2 bytes.Buffer
+	dgst1, err := RewriteTarMtimes(&dst1, bytes.NewReader(src), stamp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dgst2, err := RewriteTarMtimes(&dst2, bytes.NewReader(src), stamp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if dgst1 != dgst2 {
+		t.Fatalf("expected identical digests for identical input, got %s and %s", dgst1, dgst2)
+	}
+	if !bytes.Equal(dst1.Bytes(), dst2.Bytes()) {
+		t.Fatal("expected identical rewritten tar bytes for identical input")
+	}
+}
+
+func TestRewriteTarMtimesStampChangesDigest(t *testing.T) {
+	src := writeTar(t, map[string]string{"a": "hello"})
+	stampA := time.Unix(0, 0).UTC()
+	stampB := time.Unix(100, 0).UTC()
+
+	var dstA, dstB bytes.Buffer
+	dgstA, err := RewriteTarMtimes(&dstA, bytes.NewReader(src), stampA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dgstB, err := RewriteTarMtimes(&dstB, bytes.NewReader(src), stampB)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if dgstA == dgstB {
+		t.Fatal("expected different stamps to produce different digests")
+	}
+}
+
+func TestRewriteConfigCreated(t *testing.T) {
+	stamp := time.Unix(0, 0).UTC()
+	raw, dgst, err := RewriteConfigCreated(specs.Image{Architecture: "amd64"}, stamp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(raw) == 0 {
+		t.Fatal("expected non-empty rewritten config")
+	}
+	if dgst.String() == "" {
+		t.Fatal("expected a non-empty digest")
+	}
+
+	rawAgain, dgstAgain, err := RewriteConfigCreated(specs.Image{Architecture: "amd64"}, stamp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(raw, rawAgain) || dgst != dgstAgain {
+		t.Fatal("expected identical input to rewrite to identical config bytes and digest")
+	}
+}
+
+func TestRewriteManifestConfig(t *testing.T) {
+	manifest := specs.Manifest{Config: specs.Descriptor{Digest: "sha256:old", Size: 1}}
+	got := RewriteManifestConfig(manifest, "sha256:new", 42)
+	if got.Config.Digest != "sha256:new" || got.Config.Size != 42 {
+		t.Fatalf("expected config descriptor to be rewritten, got %+v", got.Config)
+	}
+}
+
+func TestRewriteManifestLayer(t *testing.T) {
+	manifest := specs.Manifest{Layers: []specs.Descriptor{
+		{Digest: "sha256:layer0", Size: 1},
+		{Digest: "sha256:layer1", Size: 2},
+	}}
+
+	got, err := RewriteManifestLayer(manifest, 1, "sha256:new", 99)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Layers[1].Digest != "sha256:new" || got.Layers[1].Size != 99 {
+		t.Fatalf("expected layer 1 to be rewritten, got %+v", got.Layers[1])
+	}
+	if got.Layers[0].Digest != "sha256:layer0" {
+		t.Fatalf("expected layer 0 to be untouched, got %+v", got.Layers[0])
+	}
+}
+
+func TestRewriteManifestLayerRejectsOutOfRange(t *testing.T) {
+	manifest := specs.Manifest{Layers: []specs.Descriptor{{Digest: "sha256:layer0", Size: 1}}}
+	if _, err := RewriteManifestLayer(manifest, 5, "sha256:new", 1); err == nil {
+		t.Fatal("expected an error for an out-of-range layer index")
+	}
+}
+
+func TestRewriteManifestLayerDoesNotAliasOriginal(t *testing.T) {
+	original := specs.Manifest{Layers: []specs.Descriptor{
+		{Digest: "sha256:layer0", Size: 1},
+		{Digest: "sha256:layer1", Size: 2},
+	}}
+
+	first, err := RewriteManifestLayer(original, 0, "sha256:rewritten0", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := RewriteManifestLayer(original, 1, "sha256:rewritten1", 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first.Layers[1].Digest != "sha256:layer1" {
+		t.Fatalf("expected rewriting layer 1 in a later call not to affect the first result, got %+v", first.Layers[1])
+	}
+	if second.Layers[0].Digest != "sha256:layer0" {
+		t.Fatalf("expected rewriting layer 0 in an earlier call not to affect this result, got %+v", second.Layers[0])
+	}
+	if original.Layers[0].Digest != "sha256:layer0" || original.Layers[1].Digest != "sha256:layer1" {
+		t.Fatalf("expected the original manifest's layers to stay untouched, got %+v", original.Layers)
+	}
+}
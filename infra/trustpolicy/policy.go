@@ -0,0 +1,210 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trustpolicy turns an ImageTrustPolicy into a containers/image
+// signature.PolicyContext, so the same scope matching rules can be
+// enforced both by the controller, building a policy context before every
+// ImageImport pull, and by kubectl-image, enforcing it again locally
+// before the pulled image is staged in its local image store.
+package trustpolicy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containers/image/v5/signature"
+
+	"github.com/shipwright-io/image/infra/images/v1beta1/apis"
+)
+
+// Build converts an ImageTrustPolicySpec into a signature.PolicyContext
+// enforcing whichever requirement applies to scope. scope identifies what
+// is actually being pulled (e.g. the source registry/repository for an
+// ImageImport, or the namespace/name of the Shipwright Image a
+// kubectl-image pull resolves to) — it is matched against spec.Scopes
+// ourselves, not left to containers/image's own docker-transport scope
+// matching, because neither caller ever copies from a "docker://"
+// reference: the controller copies into the mirror registry and
+// kubectl-image copies from a local docker-archive/oci layout. Those
+// transports never carry a registry scope for signature.Policy to match
+// against, so the resulting PolicyRequirements is applied as Default,
+// which containers/image enforces regardless of transport. A nil spec
+// keeps today's behavior of accepting any image, unsigned or not.
+func Build(spec *apis.ImageTrustPolicySpec, scope string) (*signature.PolicyContext, error) {
+	req, err := requirementFor(spec, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	pol := &signature.Policy{Default: req}
+	return signature.NewPolicyContext(pol)
+}
+
+// requirementFor picks the TrustRequirement that applies to scope: the
+// longest Scopes entry that prefixes it, falling back to spec.Default,
+// falling back to accepting anything when spec itself is nil.
+func requirementFor(spec *apis.ImageTrustPolicySpec, scope string) (signature.PolicyRequirements, error) {
+	if spec == nil {
+		return signature.PolicyRequirements{signature.NewPRInsecureAcceptAnything()}, nil
+	}
+
+	best := spec.Default
+	bestLen := -1
+	for i, ts := range spec.Scopes {
+		prefix := strings.TrimSuffix(ts.Scope, "/")
+		if !scopeMatches(scope, prefix) || len(prefix) <= bestLen {
+			continue
+		}
+		best = &spec.Scopes[i].Requirement
+		bestLen = len(prefix)
+	}
+
+	if best == nil {
+		return signature.PolicyRequirements{signature.NewPRInsecureAcceptAnything()}, nil
+	}
+	return requirementsFor(*best)
+}
+
+// scopeMatches reports whether scope is prefix itself, or is nested
+// under it as a "/"-delimited segment, so a TrustScope of "quay.io" only
+// ever matches "quay.io" or "quay.io/...", never a lookalike host like
+// "quay.io.evil.example.com".
+func scopeMatches(scope, prefix string) bool {
+	if !strings.HasPrefix(scope, prefix) {
+		return false
+	}
+	return len(scope) == len(prefix) || scope[len(prefix)] == '/'
+}
+
+// requirementsFor turns a single TrustRequirement into the matching
+// signature.PolicyRequirements, the containers/image primitive used to
+// describe "what must be true about this image's signatures".
+func requirementsFor(req apis.TrustRequirement) (signature.PolicyRequirements, error) {
+	switch req.Type {
+	case "", apis.TrustRequirementInsecureAcceptAnything:
+		return signature.PolicyRequirements{signature.NewPRInsecureAcceptAnything()}, nil
+
+	case apis.TrustRequirementReject:
+		return signature.PolicyRequirements{signature.NewPRReject()}, nil
+
+	case apis.TrustRequirementGPG:
+		if len(req.GPGPublicKeys) == 0 {
+			return nil, fmt.Errorf("gpg requirement without any public key")
+		}
+		keyring := []byte(strings.Join(req.GPGPublicKeys, "\n"))
+		pr, err := signature.NewPRSignedByKeyData(
+			signature.SBKeyTypeGPGKeys, keyring, signature.NewPRMMatchRepoDigestOrExact(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return signature.PolicyRequirements{pr}, nil
+
+	case apis.TrustRequirementSigstore:
+		if req.Sigstore == nil {
+			return nil, fmt.Errorf("sigstore requirement without key or identity configuration")
+		}
+		return sigstoreRequirements(*req.Sigstore)
+
+	default:
+		return nil, fmt.Errorf("unknown trust requirement type %q", req.Type)
+	}
+}
+
+// sigstoreRequirements builds the single PolicyRequirement satisfying
+// req. It must stay a single PolicyRequirement, never more: a
+// signature.Policy ANDs every entry in a PolicyRequirements slice
+// together, so appending one PolicyRequirement per accepted key/identity
+// (as this used to do) ends up requiring a signature from all of them,
+// not any one — the opposite of PublicKeys' own "any one of which is
+// enough" contract. PublicKeys gets real OR semantics by loading every
+// key into one PRSigstoreSigned requirement, the same way the GPG branch
+// above folds every key into one keyring; FulcioIdentities has no
+// equivalent multi-identity constructor, so more than one entry is
+// rejected rather than silently ANDed.
+func sigstoreRequirements(req apis.SigstoreRequirement) (signature.PolicyRequirements, error) {
+	hasKeys := len(req.PublicKeys) > 0
+	hasFulcio := len(req.FulcioIdentities) > 0
+
+	switch {
+	case !hasKeys && !hasFulcio:
+		return nil, fmt.Errorf("sigstore requirement without public keys or fulcio identities")
+	case hasKeys && hasFulcio:
+		return nil, fmt.Errorf("sigstore requirement cannot combine public keys and fulcio identities: " +
+			"containers/image ANDs separate policy requirements, which would demand both a key and a " +
+			"Fulcio signature instead of accepting either")
+	case hasKeys:
+		return sigstorePublicKeyRequirement(req.PublicKeys)
+	default:
+		return sigstoreFulcioRequirement(req.FulcioIdentities, req.RekorURL)
+	}
+}
+
+// sigstorePublicKeyRequirement accepts a signature from any one of keys,
+// loading them all into a single PRSigstoreSigned requirement so
+// containers/image's AND-only PolicyRequirements doesn't turn multiple
+// acceptable keys into "signed by all of them".
+func sigstorePublicKeyRequirement(keys []string) (signature.PolicyRequirements, error) {
+	datas := make([][]byte, len(keys))
+	for i, key := range keys {
+		datas[i] = []byte(key)
+	}
+
+	pr, err := signature.NewPRSigstoreSigned(
+		signature.PRSigstoreSignedWithKeyDatas(datas),
+		signature.PRSigstoreSignedWithSignedIdentity(signature.NewPRMMatchRepoDigestOrExact()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error loading sigstore public keys: %w", err)
+	}
+	return signature.PolicyRequirements{pr}, nil
+}
+
+// sigstoreFulcioRequirement accepts a signature matching the single
+// configured Fulcio identity. Exactly one is required: unlike
+// PRSigstoreSignedWithKeyDatas for public keys, the Fulcio requirement
+// builder only takes one issuer/subject pair, so there is no way to OR
+// multiple identities into a single requirement today.
+func sigstoreFulcioRequirement(identities []apis.FulcioIdentity, rekorURL string) (signature.PolicyRequirements, error) {
+	if len(identities) != 1 {
+		return nil, fmt.Errorf(
+			"sigstore requirement supports exactly one fulcio identity today, got %d: "+
+				"containers/image has no OR combinator across policy requirements to accept any of several",
+			len(identities),
+		)
+	}
+	id := identities[0]
+
+	fulcio, err := signature.NewPRSigstoreSignedFulcio(
+		signature.PRSigstoreSignedFulcioWithOIDCIssuer(id.Issuer),
+		signature.PRSigstoreSignedFulcioWithSubjectEmail(id.Subject),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring fulcio identity %q/%q: %w", id.Issuer, id.Subject, err)
+	}
+
+	rekorOpts := []signature.PRSigstoreSignedOption{
+		signature.PRSigstoreSignedWithFulcio(fulcio),
+		signature.PRSigstoreSignedWithSignedIdentity(signature.NewPRMMatchRepoDigestOrExact()),
+	}
+	if rekorURL != "" {
+		rekorOpts = append(rekorOpts, signature.PRSigstoreSignedWithRekorURL(rekorURL))
+	}
+
+	pr, err := signature.NewPRSigstoreSigned(rekorOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("error building fulcio requirement for %q/%q: %w", id.Issuer, id.Subject, err)
+	}
+	return signature.PolicyRequirements{pr}, nil
+}
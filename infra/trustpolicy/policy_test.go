@@ -0,0 +1,116 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trustpolicy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shipwright-io/image/infra/images/v1beta1/apis"
+)
+
+func TestRequirementForScopeMatching(t *testing.T) {
+	reject := apis.TrustRequirement{Type: apis.TrustRequirementReject}
+	insecure := apis.TrustRequirement{Type: apis.TrustRequirementInsecureAcceptAnything}
+
+	spec := &apis.ImageTrustPolicySpec{
+		Default: &insecure,
+		Scopes: []apis.TrustScope{
+			{Scope: "quay.io", Requirement: reject},
+			{Scope: "quay.io/shipwright-io", Requirement: insecure},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		scope      string
+		wantReject bool
+	}{
+		{"unrelated scope falls back to default", "docker.io/library/busybox", false},
+		{"broad scope match rejects", "quay.io/other-org/img", true},
+		{"most specific scope wins over broader one", "quay.io/shipwright-io/image", false},
+		{"exact host match rejects", "quay.io", true},
+		{"lookalike host is not a prefix match", "quay.io.evil.example.com/foo", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs, err := requirementFor(spec, tt.scope)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(reqs) != 1 {
+				t.Fatalf("expected exactly one policy requirement, got %d", len(reqs))
+			}
+
+			gotReject := strings.Contains(fmt.Sprintf("%T", reqs[0]), "Reject")
+			if gotReject != tt.wantReject {
+				t.Fatalf("scope %q: got reject=%v, want reject=%v (type %T)", tt.scope, gotReject, tt.wantReject, reqs[0])
+			}
+		})
+	}
+}
+
+func TestRequirementForNilSpecAcceptsAnything(t *testing.T) {
+	reqs, err := requirementFor(nil, "quay.io/shipwright-io/image")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reqs) != 1 {
+		t.Fatalf("expected exactly one policy requirement, got %d", len(reqs))
+	}
+}
+
+func TestSigstoreRequirementsRejectsEmptyConfig(t *testing.T) {
+	if _, err := sigstoreRequirements(apis.SigstoreRequirement{}); err == nil {
+		t.Fatal("expected an error for a sigstore requirement without keys or identities")
+	}
+}
+
+func TestSigstoreRequirementsMultipleKeysIsOneORedRequirement(t *testing.T) {
+	reqs, err := sigstoreRequirements(apis.SigstoreRequirement{
+		PublicKeys: []string{"key-one", "key-two"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reqs) != 1 {
+		t.Fatalf("expected multiple public keys to fold into exactly one policy requirement "+
+			"(so they are ORed, not ANDed), got %d", len(reqs))
+	}
+}
+
+func TestSigstoreRequirementsRejectsMultipleFulcioIdentities(t *testing.T) {
+	_, err := sigstoreRequirements(apis.SigstoreRequirement{
+		FulcioIdentities: []apis.FulcioIdentity{
+			{Issuer: "https://issuer-a", Subject: "a@example.com"},
+			{Issuer: "https://issuer-b", Subject: "b@example.com"},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error for more than one fulcio identity, since it would be ANDed, not ORed")
+	}
+}
+
+func TestSigstoreRequirementsRejectsMixingKeysAndFulcio(t *testing.T) {
+	_, err := sigstoreRequirements(apis.SigstoreRequirement{
+		PublicKeys:       []string{"key-one"},
+		FulcioIdentities: []apis.FulcioIdentity{{Issuer: "https://issuer", Subject: "a@example.com"}},
+	})
+	if err == nil {
+		t.Fatal("expected an error when combining public keys and fulcio identities")
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 The Shipwright Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePruneFilters(t *testing.T) {
+	filter, err := ParsePruneFilters([]string{"until=24h", "label=stage=ci", "dangling=true", "generations>5"}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if filter.Until == nil || *filter.Until != 24*time.Hour {
+		t.Fatalf("expected until=24h, got %v", filter.Until)
+	}
+	if filter.Labels["stage"] != "ci" {
+		t.Fatalf("expected label stage=ci, got %v", filter.Labels)
+	}
+	if filter.Dangling == nil || !*filter.Dangling {
+		t.Fatalf("expected dangling=true, got %v", filter.Dangling)
+	}
+	if filter.GenerationsGreater == nil || *filter.GenerationsGreater != 5 {
+		t.Fatalf("expected generations>5, got %v", filter.GenerationsGreater)
+	}
+}
+
+func TestParsePruneFiltersRejectsUnknown(t *testing.T) {
+	if _, err := ParsePruneFilters([]string{"bogus=1"}, false); err == nil {
+		t.Fatal("expected an error for an unrecognized filter")
+	}
+}
+
+func TestPruneFilterMatches(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	old := Generation{Digest: "sha256:old", CreatedAt: now.Add(-48 * time.Hour)}
+	recent := Generation{Digest: "sha256:new", CreatedAt: now.Add(-1 * time.Hour)}
+
+	until := 24 * time.Hour
+	filter := PruneFilter{Until: &until}
+
+	if !filter.Matches(old, 1, now) {
+		t.Fatalf("expected a generation older than the until window to match")
+	}
+	if filter.Matches(recent, 0, now) {
+		t.Fatalf("expected a generation within the until window not to match")
+	}
+}
+
+func TestPruneFilterAllMatchesEverything(t *testing.T) {
+	filter := PruneFilter{All: true}
+	if !filter.Matches(Generation{}, 0, time.Now()) {
+		t.Fatalf("expected All to match an otherwise empty generation")
+	}
+}
+
+func TestPruneFilterEmptyMatchesNothing(t *testing.T) {
+	filter := PruneFilter{}
+	if filter.Matches(Generation{}, 5, time.Now()) {
+		t.Fatalf("expected an empty filter (not All) to match nothing")
+	}
+}